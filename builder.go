@@ -1,8 +1,10 @@
 package fsm
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/d5/tengo/objects"
 	"github.com/d5/tengo/script"
@@ -12,16 +14,25 @@ import (
 // Builder represents a state machine builder that constructs and compiles
 // the state machine. Call New to create a new Builder.
 type Builder struct {
-	userScript  []byte
-	entryFns    map[string]string
-	exitFns     map[string]string
-	transitions map[string][]*transition
+	userScript      []byte
+	entryFns        map[string]string
+	exitFns         map[string]string
+	transitions     map[string][]*transition
+	imports         map[string]objects.Importable
+	stdlibNames     []string
+	noDefaultStdlib bool
+	maxTransitions  int
+	tracer          Tracer
 }
 
 // New creates a new Builder with a user script.
 //
 // User script must export functions for all condition and actions of the state
 // machine.
+//
+// By default, the compiled script can import all Tengo stdlib modules except
+// "os". Use WithStdlib, AddModule, or SetImports to change what is available
+// to import.
 func New(userScript []byte) *Builder {
 	return &Builder{
 		userScript:  userScript,
@@ -31,6 +42,60 @@ func New(userScript []byte) *Builder {
 	}
 }
 
+// SetImports replaces the entire set of modules importable from the user
+// script with 'modules'. This overrides the default stdlib allowlist (and
+// any prior WithStdlib/AddModule calls): only the modules given here (plus
+// the "user" module that always holds the user script itself) will be
+// importable.
+func (b *Builder) SetImports(modules map[string]objects.Importable) *Builder {
+	b.imports = make(map[string]objects.Importable, len(modules))
+	for name, mod := range modules {
+		b.imports[name] = mod
+	}
+	b.stdlibNames = nil
+	b.noDefaultStdlib = true
+	return b
+}
+
+// AddModule adds a single custom module (a SourceModule or BuiltinModule)
+// that the user script can import under 'name', in addition to the stdlib
+// modules selected by WithStdlib (or the default allowlist).
+func (b *Builder) AddModule(name string, mod objects.Importable) *Builder {
+	if b.imports == nil {
+		b.imports = make(map[string]objects.Importable)
+	}
+	b.imports[name] = mod
+	return b
+}
+
+// WithStdlib restricts the Tengo stdlib modules importable from the user
+// script to 'names'. Without a call to WithStdlib, all stdlib modules except
+// "os" are importable.
+func (b *Builder) WithStdlib(names ...string) *Builder {
+	b.stdlibNames = append([]string{}, names...)
+	return b
+}
+
+// buildImports assembles the import map to compile the user script with,
+// combining the selected stdlib modules with any custom modules registered
+// via AddModule, or, if SetImports was called, using that map verbatim.
+func (b *Builder) buildImports() *objects.ModuleMap {
+	var importModules *objects.ModuleMap
+	switch {
+	case b.noDefaultStdlib:
+		importModules = stdlib.GetModuleMap()
+	case b.stdlibNames != nil:
+		importModules = stdlib.GetModuleMap(b.stdlibNames...)
+	default:
+		importModules = stdlib.GetModuleMap(stdlib.AllModuleNames()...)
+		importModules.Remove("os")
+	}
+	for name, mod := range b.imports {
+		importModules.Add(name, mod)
+	}
+	return importModules
+}
+
 // State defines a state with its entry/exit action function names.
 //
 // Entry and exit action functions are optional, but, if specified, the function
@@ -99,6 +164,42 @@ func (b *Builder) Transition(src, dst, condition, action string) *Builder {
 	return b
 }
 
+// TransitionOn defines (adds) a transition from 'src' to 'dst' states that is
+// only considered when an Instance (see StateMachine.NewInstance) receives
+// 'event' via Send. It takes the same condition and action function names as
+// Transition. Unlike Transition, transitions added with TransitionOn are not
+// evaluated by Run/RunContext, and transitions added with Transition are not
+// evaluated as the direct match for an event (though they are still tried as
+// an eventless fallback if no transition tagged with that event matches).
+func (b *Builder) TransitionOn(src, dst, event, condition, action string) *Builder {
+	b.transitions[src] = append(b.transitions[src], &transition{
+		dst:       dst,
+		condition: condition,
+		action:    action,
+		event:     event,
+	})
+	return b
+}
+
+// MaxTransitions bounds the number of transitions Run/RunContext will take
+// before giving up with an error. This guards against state machines whose
+// definition causes it to transition forever (easy to write, since
+// conditions and actions are arbitrary scripts). A value of 0 (the default)
+// means unbounded.
+func (b *Builder) MaxTransitions(n int) *Builder {
+	b.maxTransitions = n
+	return b
+}
+
+// WithTracer attaches a Tracer that observes every eval, invoke, and
+// transition the resulting StateMachine performs. Without a call to
+// WithTracer, the StateMachine reports nothing; each script invocation still
+// records its start time, and every hook site checks for a nil Tracer.
+func (b *Builder) WithTracer(t Tracer) *Builder {
+	b.tracer = t
+	return b
+}
+
 // Compile compiles the script and builds the state machine. This function does
 // not validate the states and transitions. Call Validate or ValidateCompile if
 // you want to validate them.
@@ -126,8 +227,7 @@ func (b *Builder) validate() error {
 	// compile validation script
 	s := script.New(retrieveScript)
 	_ = s.Add("fn", "")
-	importModules := stdlib.GetModuleMap(stdlib.AllModuleNames()...)
-	importModules.Remove("os")
+	importModules := b.buildImports()
 	importModules.Add("user", &objects.SourceModule{Src: b.userScript})
 	s.SetImports(importModules)
 	c, err := s.Compile()
@@ -185,8 +285,7 @@ func (b *Builder) compile() (*StateMachine, error) {
 	_ = s.Add("dst", "")
 	_ = s.Add("fn", "")
 	_ = s.Add("v", nil)
-	importModules := stdlib.GetModuleMap(stdlib.AllModuleNames()...)
-	importModules.Remove("os")
+	importModules := b.buildImports()
 	importModules.Add("user", &objects.SourceModule{Src: b.userScript})
 	s.SetImports(importModules)
 	compiled, err := s.Compile()
@@ -197,11 +296,20 @@ func (b *Builder) compile() (*StateMachine, error) {
 	for src, tx := range b.transitions {
 		transitions[src] = append([]*transition{}, tx...)
 	}
+	states := make([]string, 0, len(b.entryFns))
+	for state := range b.entryFns {
+		states = append(states, state)
+	}
+	sort.Strings(states)
 	return &StateMachine{
-		invokeScript: compiled,
-		entryFns:     copyFuncMap(b.entryFns),
-		exitFns:      copyFuncMap(b.exitFns),
-		transitions:  transitions,
+		invokeScript:   compiled,
+		entryFns:       copyFuncMap(b.entryFns),
+		exitFns:        copyFuncMap(b.exitFns),
+		transitions:    transitions,
+		maxTransitions: b.maxTransitions,
+		scriptHash:     fmt.Sprintf("%x", sha256.Sum256(b.userScript)),
+		states:         states,
+		tracer:         b.tracer,
 	}, nil
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/d5/go-fsm"
 	"github.com/d5/tengo/assert"
+	"github.com/d5/tengo/objects"
 )
 
 var testScript = []byte(`
@@ -13,6 +14,7 @@ export {
 	fn2: func(src, dst, v) { return "foobar" },
 	fn3: func(src, dst) {},
 	err1: func(src, dst, v) { return error("an error occurred") },
+	isAdmin: func(src, dst, v) { return v.role == "admin" },
 	foo: [1, 2, 3]
 }`)
 
@@ -57,3 +59,37 @@ func TestBuilder_Validate(t *testing.T) {
 	err = fsm.New(testScript).State("s1", "fn1", "fn2").Transition("s1", "s1", "fn1", "fn2").Validate()
 	assert.NoError(t, err)
 }
+
+func TestBuilder_Imports(t *testing.T) {
+	// WithStdlib restricts which stdlib modules are importable
+	err := fsm.New(testScript).
+		WithStdlib("math").
+		State("s1", "fn1", "").
+		Validate()
+	assert.NoError(t, err)
+
+	// AddModule makes a custom module importable alongside the default stdlib
+	customScript := []byte(`
+	custom := import("custom")
+	export {
+		fn1: func(src, dst, v) { return custom.value }
+	}`)
+	err = fsm.New(customScript).
+		AddModule("custom", &objects.SourceModule{Src: []byte(`export {value: 42}`)}).
+		State("s1", "fn1", "").
+		Validate()
+	assert.NoError(t, err)
+
+	// SetImports replaces the whole import set; an undeclared stdlib module
+	// is no longer importable
+	stdlibScript := []byte(`
+	fmt := import("fmt")
+	export {
+		fn1: func(src, dst, v) {}
+	}`)
+	err = fsm.New(stdlibScript).
+		SetImports(map[string]objects.Importable{}).
+		State("s1", "fn1", "").
+		Validate()
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package fsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+)
+
+// configState is the declarative representation of a single state entry in a
+// state machine config document.
+type configState struct {
+	Name  string `json:"name"`
+	Entry string `json:"entry,omitempty"`
+	Exit  string `json:"exit,omitempty"`
+}
+
+// configTransition is the declarative representation of a single transition
+// entry in a state machine config document.
+type configTransition struct {
+	Src       string `json:"src"`
+	Dst       string `json:"dst"`
+	Condition string `json:"condition,omitempty"`
+	Action    string `json:"action,omitempty"`
+}
+
+// config is the top-level declarative representation of a state machine, as
+// parsed from JSON or YAML. Either Script or ScriptPath must be set: Script
+// holds the Tengo source inline, while ScriptPath points to a file containing
+// it.
+type config struct {
+	Script      string             `json:"script,omitempty"`
+	ScriptPath  string             `json:"script_path,omitempty"`
+	States      []configState      `json:"states"`
+	Transitions []configTransition `json:"transitions"`
+}
+
+// LoadConfig parses a declarative state machine definition and returns a
+// Builder populated with its states and transitions, ready for Validate,
+// Compile, or ValidateCompile. 'format' must be "json" or "yaml"; YAML input
+// is converted to JSON internally before being unmarshaled, so both formats
+// produce the same in-memory representation.
+//
+// The config document lists states (with optional 'entry'/'exit' function
+// names) and transitions (with 'src', 'dst', and optional
+// 'condition'/'action' function names), plus either an inline 'script'
+// string or a 'script_path' naming a file with the Tengo source:
+//
+//  {
+//    "script_path": "machine.tengo",
+//    "states": [
+//      {"name": "S", "entry": "enter"},
+//      {"name": "T"}
+//    ],
+//    "transitions": [
+//      {"src": "S", "dst": "T", "condition": "truthy", "action": "log"}
+//    ]
+//  }
+//
+func LoadConfig(data []byte, format string) (*Builder, error) {
+	var jsonData []byte
+	switch format {
+	case "json":
+		jsonData = data
+	case "yaml":
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert yaml to json: %s", err.Error())
+		}
+		jsonData = converted
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+
+	var c config
+	if err := json.Unmarshal(jsonData, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %s", err.Error())
+	}
+
+	userScript, err := c.loadScript()
+	if err != nil {
+		return nil, err
+	}
+
+	b := New(userScript)
+	for _, s := range c.States {
+		if s.Name == "" {
+			return nil, fmt.Errorf("state must have a name")
+		}
+		b.State(s.Name, s.Entry, s.Exit)
+	}
+	for _, t := range c.Transitions {
+		b.Transition(t.Src, t.Dst, t.Condition, t.Action)
+	}
+	return b, nil
+}
+
+// LoadFile reads the named file and calls LoadConfig with its contents.
+// 'format' must be "json" or "yaml".
+func LoadFile(filename, format string) (*Builder, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %s", err.Error())
+	}
+	return LoadConfig(data, format)
+}
+
+func (c config) loadScript() ([]byte, error) {
+	switch {
+	case c.Script != "" && c.ScriptPath != "":
+		return nil, fmt.Errorf("config must not set both 'script' and 'script_path'")
+	case c.Script != "":
+		return []byte(c.Script), nil
+	case c.ScriptPath != "":
+		data, err := ioutil.ReadFile(c.ScriptPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read script file: %s", err.Error())
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("config must set 'script' or 'script_path'")
+	}
+}
@@ -0,0 +1,66 @@
+package fsm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/d5/go-fsm"
+	"github.com/d5/tengo/assert"
+)
+
+var configScript = `
+export {
+	truthy: func(src, dst, v) { return !!v },
+	log: func(src, dst, v) {}
+}`
+
+func TestLoadConfig_JSON(t *testing.T) {
+	data, err := json.Marshal(map[string]interface{}{
+		"script": configScript,
+		"states": []map[string]string{
+			{"name": "s1"},
+			{"name": "s2"},
+		},
+		"transitions": []map[string]string{
+			{"src": "s1", "dst": "s2", "condition": "truthy", "action": "log"},
+		},
+	})
+	assert.NoError(t, err)
+
+	b, err := fsm.LoadConfig(data, "json")
+	assert.NoError(t, err)
+	assert.NoError(t, b.Validate())
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	data := []byte(`
+script: |
+  export {
+    truthy: func(src, dst, v) { return !!v },
+    log: func(src, dst, v) {}
+  }
+states:
+  - name: s1
+  - name: s2
+transitions:
+  - src: s1
+    dst: s2
+    condition: truthy
+    action: log
+`)
+
+	b, err := fsm.LoadConfig(data, "yaml")
+	assert.NoError(t, err)
+	assert.NoError(t, b.Validate())
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	_, err := fsm.LoadConfig([]byte(`{}`), "json")
+	assert.Error(t, err)
+
+	_, err = fsm.LoadConfig([]byte(`{"script": "x"}`), "toml")
+	assert.Error(t, err)
+
+	_, err = fsm.LoadConfig([]byte(`{"script": "x", "script_path": "y"}`), "json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package fsm
+
+import (
+	"fmt"
+	"io"
+)
+
+// TransitionInfo is a read-only description of a single transition, as
+// returned by StateMachine.Transitions for introspection, diagram export, or
+// building a custom visualizer.
+type TransitionInfo struct {
+	Dst       string
+	Condition string
+	Action    string
+	Event     string
+}
+
+// States returns the names of all states defined on the StateMachine, in
+// sorted order.
+func (m *StateMachine) States() []string {
+	return append([]string{}, m.states...)
+}
+
+// Transitions returns read-only information about every transition defined
+// from state 'src', in the order they were added (and so, the order they
+// are evaluated in).
+func (m *StateMachine) Transitions(src string) []TransitionInfo {
+	transitions := m.transitions[src]
+	info := make([]TransitionInfo, len(transitions))
+	for i, t := range transitions {
+		info[i] = TransitionInfo{
+			Dst:       t.dst,
+			Condition: t.condition,
+			Action:    t.action,
+			Event:     t.event,
+		}
+	}
+	return info
+}
+
+// ExportDOT writes the state machine's graph to 'w' in Graphviz DOT format,
+// with nodes labeled by state name (and entry/exit function annotations)
+// and edges labeled by condition/action (and event, for event-driven
+// transitions).
+func (m *StateMachine) ExportDOT(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph fsm {"); err != nil {
+		return err
+	}
+	for _, state := range m.states {
+		if _, err := fmt.Fprintf(w, "\t%q [label=%q];\n", state, nodeLabel(state, m.entryFns[state], m.exitFns[state])); err != nil {
+			return err
+		}
+	}
+	for _, state := range m.states {
+		for _, t := range m.transitions[state] {
+			if _, err := fmt.Fprintf(w, "\t%q -> %q [label=%q];\n", state, t.dst, edgeLabel(t)); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// ExportMermaid writes the state machine's graph to 'w' as a Mermaid
+// stateDiagram-v2 document, with nodes labeled by state name (and
+// entry/exit function annotations) and edges labeled by condition/action
+// (and event, for event-driven transitions).
+func (m *StateMachine) ExportMermaid(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "stateDiagram-v2"); err != nil {
+		return err
+	}
+	for _, state := range m.states {
+		if label := nodeLabel(state, m.entryFns[state], m.exitFns[state]); label != state {
+			if _, err := fmt.Fprintf(w, "\t%s: %s\n", state, label); err != nil {
+				return err
+			}
+		}
+	}
+	for _, state := range m.states {
+		for _, t := range m.transitions[state] {
+			if _, err := fmt.Fprintf(w, "\t%s --> %s: %s\n", state, t.dst, edgeLabel(t)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func nodeLabel(state, entryFn, exitFn string) string {
+	label := state
+	if entryFn != "" {
+		label += fmt.Sprintf(" / entry: %s", entryFn)
+	}
+	if exitFn != "" {
+		label += fmt.Sprintf(" / exit: %s", exitFn)
+	}
+	return label
+}
+
+func edgeLabel(t *transition) string {
+	label := t.condition
+	if label == "" {
+		label = "*"
+	}
+	if t.event != "" {
+		label = fmt.Sprintf("on %s / %s", t.event, label)
+	}
+	if t.action != "" {
+		label += " / " + t.action
+	}
+	return label
+}
@@ -0,0 +1,58 @@
+package fsm_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/d5/tengo/assert"
+)
+
+func TestStateMachine_Introspection(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		Transition("s1", "s2", "fn1", "fn2").
+		Compile()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"s1", "s2"}, machine.States())
+
+	transitions := machine.Transitions("s1")
+	assert.Equal(t, 1, len(transitions))
+	assert.Equal(t, "s2", transitions[0].Dst)
+	assert.Equal(t, "fn1", transitions[0].Condition)
+	assert.Equal(t, "fn2", transitions[0].Action)
+}
+
+func TestStateMachine_ExportDOT(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		Transition("s1", "s2", "fn1", "fn2").
+		Compile()
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, machine.ExportDOT(&buf))
+	out := buf.String()
+
+	assert.Equal(t, true, strings.HasPrefix(out, "digraph fsm {"))
+	assert.Equal(t, true, strings.Contains(out, `"s1"`))
+	assert.Equal(t, true, strings.Contains(out, `"s2"`))
+	assert.Equal(t, true, strings.Contains(out, `"s1" -> "s2"`))
+	assert.Equal(t, true, strings.Contains(out, "fn1"))
+	assert.Equal(t, true, strings.Contains(out, "fn2"))
+}
+
+func TestStateMachine_ExportMermaid(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		Transition("s1", "s2", "fn1", "fn2").
+		Compile()
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, machine.ExportMermaid(&buf))
+	out := buf.String()
+
+	assert.Equal(t, true, strings.HasPrefix(out, "stateDiagram-v2\n"))
+	assert.Equal(t, true, strings.Contains(out, "s1 --> s2"))
+	assert.Equal(t, true, strings.Contains(out, "fn1"))
+	assert.Equal(t, true, strings.Contains(out, "fn2"))
+}
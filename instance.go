@@ -0,0 +1,168 @@
+package fsm
+
+import (
+	"context"
+
+	"github.com/d5/tengo/script"
+)
+
+// Instance is a single, reusable evaluation of a StateMachine that advances
+// one step at a time in response to external events, rather than draining
+// all transitions in one call like Run does. Use StateMachine.NewInstance to
+// create one, and Send to deliver events to it.
+type Instance struct {
+	machine      *StateMachine
+	invokeScript *script.Compiled
+	state        string
+	value        *script.Variable
+	err          error
+}
+
+// NewInstance creates an Instance starting at state 'src' with data value
+// 'v'. See
+// https://github.com/d5/tengo/blob/master/docs/interoperability.md#type-conversion-table
+// for data value conversions; a conversion error is returned by the first
+// call to Send.
+//
+// Each Instance clones the StateMachine's compiled invoke script (see
+// Compiled.Clone), so distinct Instances of one StateMachine never share
+// invoke-script state; a single Instance, like a single StateMachine, must
+// still only be driven by one goroutine at a time.
+func (m *StateMachine) NewInstance(src string, v interface{}) *Instance {
+	value, err := script.NewVariable("", v)
+	return &Instance{machine: m, invokeScript: m.invokeScript.Clone(), state: src, value: value, err: err}
+}
+
+// State returns the Instance's current state name.
+func (i *Instance) State() string {
+	return i.state
+}
+
+// Value returns the Instance's current data value.
+func (i *Instance) Value() *script.Variable {
+	return i.value
+}
+
+// Send delivers 'event' (with an optional 'payload') to the Instance. Only
+// transitions from the current state tagged with 'event' (via
+// Builder.TransitionOn) are considered; if none of them match, eventless
+// transitions (added via Builder.Transition) are tried as a fallback. Guard
+// conditions are evaluated against 'payload' merged into the Instance's
+// current value (see mergeValue). If a transition matches, Send runs its
+// exit/action/entry functions, advances the Instance to the destination
+// state, and returns the resulting value. If no transition matches, Send
+// returns the Instance's unchanged current value.
+func (i *Instance) Send(event string, payload interface{}) (*script.Variable, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+
+	ctx := context.Background()
+	in, err := mergeValue(i.value, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := i.machine.evalEvent(ctx, i.invokeScript, i.state, event, in)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return i.value, nil
+	}
+
+	out, err := i.machine.doTransition(ctx, i.invokeScript, i.state, t.dst, t.action, in)
+	if err != nil {
+		return nil, err
+	}
+	i.state = t.dst
+	i.value = out
+	return out, nil
+}
+
+// evalEvent finds the first transition from 'src' tagged with 'event' whose
+// condition passes, falling back to eventless transitions if 'event' itself
+// has no match.
+func (m *StateMachine) evalEvent(
+	ctx context.Context,
+	invokeScript *script.Compiled,
+	src, event string,
+	in *script.Variable,
+) (*transition, error) {
+	if m.tracer != nil {
+		m.tracer.OnEval(src, in)
+	}
+
+	transitions, ok := m.transitions[src]
+	if !ok {
+		return nil, nil
+	}
+
+	if t, err := matchEvent(ctx, m, invokeScript, src, transitions, event, in); t != nil || err != nil {
+		return t, err
+	}
+	if event != "" {
+		return matchEvent(ctx, m, invokeScript, src, transitions, "", in)
+	}
+	return nil, nil
+}
+
+func matchEvent(
+	ctx context.Context,
+	m *StateMachine,
+	invokeScript *script.Compiled,
+	src string,
+	transitions []*transition,
+	event string,
+	in *script.Variable,
+) (*transition, error) {
+	for _, t := range transitions {
+		if t.event != event {
+			continue
+		}
+		if t.condition == "" {
+			return t, nil
+		}
+		out, err := m.invoke(ctx, invokeScript, src, t.dst, t.condition, in)
+		if err != nil {
+			return nil, err
+		}
+		if out.Bool() {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// mergeValue merges 'payload' into 'value' to produce the data value guard
+// conditions and actions see for a Send call. If 'payload' is nil, 'value'
+// is returned unchanged. If the current value is a map[string]interface{},
+// 'payload' is merged into a copy of it: key-by-key if 'payload' is itself a
+// map[string]interface{} ('payload' wins on conflict), or under a "payload"
+// key otherwise. If the current value is not a map, the result is
+// {"value": value, "payload": payload}.
+func mergeValue(value *script.Variable, payload interface{}) (*script.Variable, error) {
+	if payload == nil {
+		return value, nil
+	}
+
+	if valueMap, ok := value.Value().(map[string]interface{}); ok {
+		merged := make(map[string]interface{}, len(valueMap))
+		for k, v := range valueMap {
+			merged[k] = v
+		}
+		if payloadMap, ok := payload.(map[string]interface{}); ok {
+			for k, v := range payloadMap {
+				merged[k] = v
+			}
+		} else {
+			merged["payload"] = payload
+		}
+		return script.NewVariable("", merged)
+	}
+
+	return script.NewVariable("", map[string]interface{}{
+		"value":   value.Value(),
+		"payload": payload,
+	})
+}
@@ -0,0 +1,119 @@
+package fsm_test
+
+import (
+	"testing"
+
+	"github.com/d5/go-fsm"
+	"github.com/d5/tengo/assert"
+)
+
+// newTwoStateBuilder returns a Builder pre-populated with states "s1" and
+// "s2" on testScript, shared by the tests in this package that only need a
+// simple two-state fixture to hang their own transitions/tracers off of.
+func newTwoStateBuilder() *fsm.Builder {
+	return fsm.New(testScript).State("s1", "", "").State("s2", "", "")
+}
+
+func TestInstance_Send(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		TransitionOn("s1", "s2", "go", "", "fn1").
+		Compile()
+	assert.NoError(t, err)
+
+	instance := machine.NewInstance("s1", 123)
+	assert.Equal(t, "s1", instance.State())
+
+	// an unrelated event does not match the "go"-tagged transition
+	out, err := instance.Send("stop", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "s1", instance.State())
+	assert.Equal(t, int64(123), out.Value())
+
+	// the matching event advances the instance
+	out, err = instance.Send("go", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "s2", instance.State())
+	assert.Equal(t, int64(123), out.Value())
+}
+
+func TestInstance_SendEventlessFallback(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		Transition("s1", "s2", "", "fn2"). // eventless, used as fallback
+		Compile()
+	assert.NoError(t, err)
+
+	instance := machine.NewInstance("s1", 123)
+	out, err := instance.Send("anything", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "s2", instance.State())
+	assert.Equal(t, "foobar", out.Value())
+}
+
+func TestInstance_Send_MergePayload_MapValue(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		TransitionOn("s1", "s2", "go", "", "fn1"). // fn1 is a no-op, so 'out' is the merged value itself
+		Compile()
+	assert.NoError(t, err)
+
+	instance := machine.NewInstance("s1", map[string]interface{}{
+		"role": "guest",
+		"name": "alice",
+	})
+	out, err := instance.Send("go", map[string]interface{}{
+		"role": "admin", // collides with the current value's "role" key; payload wins
+	})
+	assert.NoError(t, err)
+	merged := out.Value().(map[string]interface{})
+	assert.Equal(t, "admin", merged["role"])
+	assert.Equal(t, "alice", merged["name"])
+}
+
+func TestInstance_Send_MergePayload_MapValueNonMapPayload(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		TransitionOn("s1", "s2", "go", "", "fn1"). // fn1 is a no-op, so 'out' is the merged value itself
+		Compile()
+	assert.NoError(t, err)
+
+	instance := machine.NewInstance("s1", map[string]interface{}{
+		"role": "guest",
+		"name": "alice",
+	})
+	out, err := instance.Send("go", "urgent") // payload isn't a map, so it's merged under "payload" instead of {"value", "payload"}
+	assert.NoError(t, err)
+	merged := out.Value().(map[string]interface{})
+	assert.Equal(t, "guest", merged["role"])
+	assert.Equal(t, "alice", merged["name"])
+	assert.Equal(t, "urgent", merged["payload"])
+}
+
+func TestInstance_Send_MergePayload_NonMapValue(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		TransitionOn("s1", "s2", "go", "", "fn1"). // fn1 is a no-op, so 'out' is the merged value itself
+		Compile()
+	assert.NoError(t, err)
+
+	instance := machine.NewInstance("s1", 123) // not a map, so payload is wrapped instead of merged
+	out, err := instance.Send("go", "urgent")
+	assert.NoError(t, err)
+	merged := out.Value().(map[string]interface{})
+	assert.Equal(t, int64(123), merged["value"])
+	assert.Equal(t, "urgent", merged["payload"])
+}
+
+func TestInstance_Send_GuardSeesMergedPayload(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		TransitionOn("s1", "s2", "go", "isAdmin", "fn1").
+		Compile()
+	assert.NoError(t, err)
+
+	// the guard only passes once the payload merges in "role": "admin"
+	instance := machine.NewInstance("s1", map[string]interface{}{"role": "guest"})
+	_, err = instance.Send("go", map[string]interface{}{"role": "guest"})
+	assert.NoError(t, err)
+	assert.Equal(t, "s1", instance.State())
+
+	out, err := instance.Send("go", map[string]interface{}{"role": "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "s2", instance.State())
+	assert.Equal(t, "admin", out.Value().(map[string]interface{})["role"])
+}
@@ -0,0 +1,93 @@
+package fsm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// snapshotVersion is bumped whenever the snapshot wire format changes.
+const snapshotVersion = 1
+
+// snapshot is the portable, on-disk representation of an Instance, as
+// produced by Instance.Snapshot and consumed by StateMachine.Restore.
+type snapshot struct {
+	Version    int         `json:"version"`
+	ScriptHash string      `json:"script_hash"`
+	State      string      `json:"state"`
+	Value      interface{} `json:"value"`
+}
+
+// Snapshot encodes the Instance's current state name and data value as JSON,
+// so it can be persisted (to disk, a database, etc.) and later resumed with
+// StateMachine.Restore. The snapshot carries a version tag and a hash of the
+// compiled user script, so Restore can refuse a snapshot produced against an
+// incompatible state machine definition.
+func (i *Instance) Snapshot() ([]byte, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	return json.Marshal(snapshot{
+		Version:    snapshotVersion,
+		ScriptHash: i.machine.scriptHash,
+		State:      i.state,
+		Value:      i.value.Value(),
+	})
+}
+
+// Restore decodes a snapshot produced by Instance.Snapshot and returns an
+// Instance resuming from the state and data value it captured. Restore
+// fails if the snapshot's version isn't understood, or if its script hash
+// does not match this StateMachine, since that means it was produced
+// against a different (and possibly incompatible) machine definition.
+func (m *StateMachine) Restore(data []byte) (*Instance, error) {
+	// UseNumber so integer values round-trip as int64 (via numberToValue)
+	// instead of silently widening to float64, which is encoding/json's
+	// default behavior when decoding into interface{}.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var s snapshot
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %s", err.Error())
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %d", s.Version)
+	}
+	if s.ScriptHash != m.scriptHash {
+		return nil, fmt.Errorf("snapshot was produced by an incompatible state machine definition")
+	}
+
+	instance := m.NewInstance(s.State, numberToValue(s.Value))
+	if instance.err != nil {
+		return nil, instance.err
+	}
+	return instance, nil
+}
+
+// numberToValue recursively replaces the json.Number leaves UseNumber
+// produces (within maps and slices too) with an int64 if the number parses as
+// an integer, or a float64 otherwise. Integers therefore keep their int64
+// type, but a float64 with an integral value (e.g. 2.0, encoded as "2") is
+// restored as an int64.
+func numberToValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = numberToValue(e)
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = numberToValue(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
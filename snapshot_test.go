@@ -0,0 +1,77 @@
+package fsm_test
+
+import (
+	"testing"
+
+	"github.com/d5/go-fsm"
+	"github.com/d5/tengo/assert"
+)
+
+func TestInstance_SnapshotRestore(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		TransitionOn("s1", "s2", "go", "", "fn1").
+		Compile()
+	assert.NoError(t, err)
+
+	instance := machine.NewInstance("s1", 123)
+	_, err = instance.Send("go", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "s2", instance.State())
+
+	data, err := instance.Snapshot()
+	assert.NoError(t, err)
+
+	restored, err := machine.Restore(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "s2", restored.State())
+	// the restored value must keep its original Go type (int64), not widen
+	// to float64 the way a plain encoding/json round-trip would.
+	assert.Equal(t, int64(123), restored.Value().Value())
+}
+
+func TestInstance_SnapshotRestore_NestedIntegers(t *testing.T) {
+	machine, err := newTwoStateBuilder().
+		TransitionOn("s1", "s2", "go", "", "fn1").
+		Compile()
+	assert.NoError(t, err)
+
+	instance := machine.NewInstance("s1", map[string]interface{}{
+		"count": 7,
+		"items": []interface{}{1, 2, 3},
+	})
+	_, err = instance.Send("go", nil)
+	assert.NoError(t, err)
+
+	data, err := instance.Snapshot()
+	assert.NoError(t, err)
+
+	restored, err := machine.Restore(data)
+	assert.NoError(t, err)
+	value := restored.Value().Value().(map[string]interface{})
+	assert.Equal(t, int64(7), value["count"])
+
+	items := value["items"].([]interface{})
+	assert.Equal(t, 3, len(items))
+	assert.Equal(t, int64(1), items[0])
+	assert.Equal(t, int64(2), items[1])
+	assert.Equal(t, int64(3), items[2])
+}
+
+func TestStateMachine_RestoreIncompatible(t *testing.T) {
+	machine, err := fsm.New(testScript).
+		State("s1", "", "").
+		Compile()
+	assert.NoError(t, err)
+
+	otherScript := append(append([]byte{}, testScript...), '\n')
+	other, err := fsm.New(otherScript).
+		State("s1", "", "").
+		Compile()
+	assert.NoError(t, err)
+
+	data, err := other.NewInstance("s1", 123).Snapshot()
+	assert.NoError(t, err)
+
+	_, err = machine.Restore(data)
+	assert.Error(t, err)
+}
@@ -1,7 +1,10 @@
 package fsm
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/d5/tengo/objects"
 	"github.com/d5/tengo/script"
@@ -10,10 +13,14 @@ import (
 // StateMachine represents a compiled state machine. Use Builder to
 // construct and compile StateMachine.
 type StateMachine struct {
-	invokeScript *script.Compiled
-	entryFns     map[string]string
-	exitFns      map[string]string
-	transitions  map[string][]*transition
+	invokeScript   *script.Compiled
+	entryFns       map[string]string
+	exitFns        map[string]string
+	transitions    map[string][]*transition
+	maxTransitions int
+	scriptHash     string
+	states         []string
+	tracer         Tracer
 }
 
 // Run executes the state machine from an initial state 'src' and an input data
@@ -23,37 +30,77 @@ type StateMachine struct {
 // states, until there are no more transitions available. When it stops, Run
 // returns the final output value 'out' or an error 'err' if a script returned
 // an error while executing.
+//
+// Run is equivalent to RunContext with context.Background().
 func (m *StateMachine) Run(
 	src string,
 	in interface{},
+) (out *script.Variable, err error) {
+	return m.RunContext(context.Background(), src, in)
+}
+
+// RunContext is like Run, but carries a context.Context into every script
+// invocation and checks ctx.Err() between transitions. If ctx is canceled or
+// its deadline passes, RunContext stops and returns ctx.Err(). This lets
+// callers bound how long a state machine (and the Tengo scripts it runs) may
+// run, which matters when embedding a machine whose definition may contain
+// an unbounded auto-transition loop; see also Builder.MaxTransitions.
+func (m *StateMachine) RunContext(
+	ctx context.Context,
+	src string,
+	in interface{},
 ) (out *script.Variable, err error) {
 	value, err := script.NewVariable("", in)
 	if err != nil {
 		return nil, err
 	}
 
-	for {
-		t, err := m.eval(src, value)
+	// Clone the compiled invoke script so this call's Set/RunContext
+	// sequence can't interleave with another concurrent Run/RunContext (or
+	// Instance.Send) call on the same StateMachine; see Compiled.Clone.
+	invokeScript := m.invokeScript.Clone()
+
+	state := src
+	defer func() {
+		if m.tracer != nil {
+			m.tracer.OnStop(state, out, err)
+		}
+	}()
+
+	for i := 0; ; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		t, err := m.eval(ctx, invokeScript, state, value)
 		if err != nil {
 			return nil, err
 		}
 		if t == nil {
 			// no more transition
-			break
+			return value, nil
+		}
+		if m.maxTransitions > 0 && i >= m.maxTransitions {
+			return nil, fmt.Errorf("exceeded max transitions (%d)", m.maxTransitions)
 		}
-		value, err = m.doTransition(src, t.dst, t.action, value)
+		value, err = m.doTransition(ctx, invokeScript, state, t.dst, t.action, value)
 		if err != nil {
 			return nil, err
 		}
-		src = t.dst
+		state = t.dst
 	}
-	return value, nil
 }
 
 func (m *StateMachine) eval(
+	ctx context.Context,
+	invokeScript *script.Compiled,
 	src string,
 	in *script.Variable,
 ) (*transition, error) {
+	if m.tracer != nil {
+		m.tracer.OnEval(src, in)
+	}
+
 	transitions, ok := m.transitions[src]
 	if !ok {
 		// no transition found
@@ -61,10 +108,14 @@ func (m *StateMachine) eval(
 	}
 
 	for _, t := range transitions {
+		if t.event != "" {
+			// only considered for Instance.Send with a matching event
+			continue
+		}
 		if t.condition == "" {
 			return t, nil
 		}
-		out, err := m.invoke(src, t.dst, t.condition, in)
+		out, err := m.invoke(ctx, invokeScript, src, t.dst, t.condition, in)
 		if err != nil {
 			return nil, err
 		}
@@ -76,11 +127,20 @@ func (m *StateMachine) eval(
 }
 
 func (m *StateMachine) doTransition(
+	ctx context.Context,
+	invokeScript *script.Compiled,
 	src, dst, action string,
 	in *script.Variable,
-) (*script.Variable, error) {
+) (out *script.Variable, err error) {
+	before := in
+	defer func() {
+		if m.tracer != nil && err == nil {
+			m.tracer.OnTransition(src, dst, action, before, out)
+		}
+	}()
+
 	if exitFn := m.exitFns[src]; exitFn != "" {
-		out, err := m.invoke(src, dst, exitFn, in)
+		out, err := m.invoke(ctx, invokeScript, src, dst, exitFn, in)
 		if err != nil {
 			return nil, err
 		}
@@ -90,7 +150,7 @@ func (m *StateMachine) doTransition(
 	}
 
 	if action != "" {
-		out, err := m.invoke(src, dst, action, in)
+		out, err := m.invoke(ctx, invokeScript, src, dst, action, in)
 		if err != nil {
 			return nil, err
 		}
@@ -100,7 +160,7 @@ func (m *StateMachine) doTransition(
 	}
 
 	if entryFn := m.entryFns[dst]; entryFn != "" {
-		out, err := m.invoke(src, dst, entryFn, in)
+		out, err := m.invoke(ctx, invokeScript, src, dst, entryFn, in)
 		if err != nil {
 			return nil, err
 		}
@@ -112,19 +172,28 @@ func (m *StateMachine) doTransition(
 }
 
 func (m *StateMachine) invoke(
+	ctx context.Context,
+	invokeScript *script.Compiled,
 	src, dst, fn string,
 	in *script.Variable,
 ) (out *script.Variable, err error) {
-	_ = m.invokeScript.Set("src", &objects.String{Value: src})
-	_ = m.invokeScript.Set("dst", &objects.String{Value: dst})
-	_ = m.invokeScript.Set("fn", &objects.String{Value: fn})
-	_ = m.invokeScript.Set("v", in.Object())
-	err = m.invokeScript.Run()
+	start := time.Now()
+	defer func() {
+		if m.tracer != nil {
+			m.tracer.OnInvoke(src, dst, fn, in, out, err, time.Since(start))
+		}
+	}()
+
+	_ = invokeScript.Set("src", &objects.String{Value: src})
+	_ = invokeScript.Set("dst", &objects.String{Value: dst})
+	_ = invokeScript.Set("fn", &objects.String{Value: fn})
+	_ = invokeScript.Set("v", in.Object())
+	err = invokeScript.RunContext(ctx)
 	if err != nil {
 		return
 	}
 
-	out = m.invokeScript.Get("out")
+	out = invokeScript.Get("out")
 	if out, isErr := out.Object().(*objects.Error); isErr {
 		return nil, errors.New(out.String())
 	}
@@ -1,10 +1,11 @@
 package fsm_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/d5/go-fsm"
-	"github.com/d5/tengo/v2/require"
+	"github.com/d5/tengo/assert"
 )
 
 func TestStateMachine_Run(t *testing.T) {
@@ -13,27 +14,52 @@ func TestStateMachine_Run(t *testing.T) {
 		State("s2", "", "").
 		Transition("s1", "s2", "", "fn1"). // value not changed
 		Compile()
-	require.NoError(t, err)
+	assert.NoError(t, err)
 	out, err := machine.Run("s1", 123)
-	require.NoError(t, err)
-	require.Equal(t, int64(123), out.Value())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(123), out.Value())
 
 	machine, err = fsm.New(testScript).
 		State("s1", "", "").
 		State("s2", "", "").
 		Transition("s1", "s2", "", "fn2"). // change it to "foobar"
 		Compile()
-	require.NoError(t, err)
+	assert.NoError(t, err)
 	out, err = machine.Run("s1", 123)
-	require.NoError(t, err)
-	require.Equal(t, "foobar", out.Value())
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", out.Value())
 
 	machine, err = fsm.New(testScript).
 		State("s1", "", "").
 		State("s2", "", "").
 		Transition("s1", "s2", "", "err1"). // error returned
 		Compile()
-	require.NoError(t, err)
+	assert.NoError(t, err)
 	_, err = machine.Run("s1", 123)
-	require.Error(t, err)
+	assert.Error(t, err)
+}
+
+func TestStateMachine_RunContext(t *testing.T) {
+	machine, err := fsm.New(testScript).
+		State("s1", "", "").
+		State("s2", "", "").
+		Transition("s1", "s2", "", "fn1").
+		Compile()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = machine.RunContext(ctx, "s1", 123)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestStateMachine_MaxTransitions(t *testing.T) {
+	machine, err := fsm.New(testScript).
+		State("s1", "", "").
+		Transition("s1", "s1", "", "fn1"). // transitions to itself forever
+		MaxTransitions(3).
+		Compile()
+	assert.NoError(t, err)
+	_, err = machine.Run("s1", 123)
+	assert.Error(t, err)
 }
@@ -0,0 +1,82 @@
+package fsm
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/d5/tengo/script"
+)
+
+// Tracer observes a StateMachine's execution: every state re-evaluation,
+// every script function invocation, every transition taken, and the final
+// outcome of a Run/RunContext call. Attach one with Builder.WithTracer.
+// A StateMachine (and each Instance created from it) clones its compiled
+// invoke script per Run/RunContext call or per Instance, so concurrent
+// Run/RunContext calls on one StateMachine, and Send calls on distinct
+// Instances of it, don't share invoke-script state; however a single
+// Run/RunContext call, or a single Instance, must still only be driven by
+// one goroutine at a time, and its Tracer methods are called from that same
+// goroutine.
+type Tracer interface {
+	// OnEval is called each time the state machine (re-)evaluates the
+	// transitions available from 'src' with the current data value.
+	OnEval(src string, value *script.Variable)
+
+	// OnInvoke is called after a script function 'fn' (an entry/exit
+	// function, a condition, or an action) has run from 'src' to 'dst', with
+	// its input 'in', output 'out' (nil if err is non-nil), any error, and
+	// how long it took.
+	OnInvoke(src, dst, fn string, in, out *script.Variable, err error, dur time.Duration)
+
+	// OnTransition is called after a transition from 'src' to 'dst' (with
+	// action function name 'action', which may be empty) has completed
+	// successfully, with the data value before and after it ran.
+	OnTransition(src, dst, action string, before, after *script.Variable)
+
+	// OnStop is called once, when a Run/RunContext call stops: 'state' is
+	// the state it stopped in, 'final' is the resulting data value (nil if
+	// err is non-nil), and 'err' is the error that stopped it, if any.
+	OnStop(state string, final *script.Variable, err error)
+}
+
+// logTracer is a Tracer that writes a line per event to an io.Writer.
+type logTracer struct {
+	w io.Writer
+}
+
+// NewLogTracer returns a Tracer that writes a human-readable line to 'w' for
+// every eval, invoke, transition, and stop. It's meant for local development
+// and debugging; use NewPrometheusTracer for production observability.
+func NewLogTracer(w io.Writer) Tracer {
+	return &logTracer{w: w}
+}
+
+func (t *logTracer) OnEval(src string, value *script.Variable) {
+	fmt.Fprintf(t.w, "eval: %s %v\n", src, value)
+}
+
+func (t *logTracer) OnInvoke(
+	src, dst, fn string,
+	in, out *script.Variable,
+	err error,
+	dur time.Duration,
+) {
+	if err != nil {
+		fmt.Fprintf(t.w, "invoke: %s -> %s: %s(%v) failed after %s: %s\n", src, dst, fn, in, dur, err)
+		return
+	}
+	fmt.Fprintf(t.w, "invoke: %s -> %s: %s(%v) = %v (%s)\n", src, dst, fn, in, out, dur)
+}
+
+func (t *logTracer) OnTransition(src, dst, action string, before, after *script.Variable) {
+	fmt.Fprintf(t.w, "transition: %s -> %s (action=%q): %v -> %v\n", src, dst, action, before, after)
+}
+
+func (t *logTracer) OnStop(state string, final *script.Variable, err error) {
+	if err != nil {
+		fmt.Fprintf(t.w, "stop: %s: %s\n", state, err)
+		return
+	}
+	fmt.Fprintf(t.w, "stop: %s: %v\n", state, final)
+}
@@ -0,0 +1,28 @@
+package fsm_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/d5/go-fsm"
+	"github.com/d5/tengo/assert"
+)
+
+func TestStateMachine_WithTracer_Log(t *testing.T) {
+	var buf bytes.Buffer
+	machine, err := newTwoStateBuilder().
+		Transition("s1", "s2", "", "fn1").
+		WithTracer(fsm.NewLogTracer(&buf)).
+		Compile()
+	assert.NoError(t, err)
+
+	_, err = machine.Run("s1", 123)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Equal(t, true, strings.Contains(out, "eval: s1"))
+	assert.Equal(t, true, strings.Contains(out, "invoke: s1 -> s2: fn1"))
+	assert.Equal(t, true, strings.Contains(out, "transition: s1 -> s2"))
+	assert.Equal(t, true, strings.Contains(out, "stop: s2"))
+}
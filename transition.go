@@ -0,0 +1,15 @@
+package fsm
+
+// transition represents a single state transition: from the state it is
+// attached to (implicit, see Builder.transitions/StateMachine.transitions)
+// to 'dst', guarded by an optional 'condition' function and followed by an
+// optional 'action' function. A non-empty 'event' restricts the transition
+// to Instance.Send calls for that event name; an empty 'event' is an
+// eventless transition, evaluated by Run/RunContext and used as a fallback
+// by Send when no event-tagged transition matches.
+type transition struct {
+	dst       string
+	condition string
+	action    string
+	event     string
+}